@@ -0,0 +1,429 @@
+// Package mcp implements a client for the Model Context Protocol's
+// Streamable HTTP transport: https://modelcontextprotocol.io/specification
+// A single endpoint accepts JSON-RPC requests over POST and replies with
+// either a plain `application/json` body or a `text/event-stream` of
+// JSON-RPC messages, optionally tagged with an `Mcp-Session-Id`.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ProtocolVersion is the MCP protocol revision this client speaks.
+const ProtocolVersion = "2024-11-05"
+
+// Request represents a JSON-RPC request (or notification, when ID is zero).
+type Request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// Response represents a JSON-RPC response.
+type Response struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// Error represents a JSON-RPC error object.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// Client speaks the MCP Streamable HTTP transport against a single
+// endpoint URL.
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+
+	deadlineTimer
+
+	mu        sync.Mutex
+	nextID    int
+	sessionID string
+}
+
+// NewClient creates a client for the MCP endpoint at the given URL. The
+// underlying http.Client has no fixed Timeout: http.Client.Timeout bounds
+// the entire exchange including body reads, which would cut off every
+// text/event-stream response - and the long-lived Notifications channel -
+// at a fixed wall-clock point regardless of the caller's ctx. Callers get
+// cancellation instead via ctx and the deadlineTimer's SetDeadline family.
+func NewClient(endpoint string) *Client {
+	c := &Client{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{},
+		nextID:     1,
+	}
+	c.deadlineTimer.init()
+	return c
+}
+
+// Initialize performs the MCP handshake, capturing the Mcp-Session-Id the
+// server assigns for use on every subsequent request.
+func (c *Client) Initialize(ctx context.Context, clientName, clientVersion string) (*Response, error) {
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"clientInfo": map[string]interface{}{
+			"name":    clientName,
+			"version": clientVersion,
+		},
+	}
+
+	resp, err := c.call(ctx, "initialize", params)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: initialize: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("mcp: initialize: %w", resp.Error)
+	}
+
+	// Per spec, the client must send an "initialized" notification once
+	// the handshake completes.
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, fmt.Errorf("mcp: initialized notification: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ListTools fetches the server's tool catalog.
+func (c *Client) ListTools(ctx context.Context) ([]interface{}, error) {
+	resp, err := c.call(ctx, "tools/list", map[string]interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("mcp: tools/list: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("mcp: tools/list: %w", resp.Error)
+	}
+
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mcp: tools/list: unexpected result shape")
+	}
+	tools, ok := result["tools"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mcp: tools/list: missing tools array")
+	}
+	return tools, nil
+}
+
+// CallTool invokes the named tool with the given arguments.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (interface{}, error) {
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	}
+
+	resp, err := c.call(ctx, "tools/call", params)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: tools/call %s: %w", name, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("mcp: tools/call %s: %w", name, resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// Close terminates the session by sending a DELETE to the endpoint, as
+// the spec requires clients to do when they are done with a session.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.mu.Unlock()
+
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.Endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("mcp: building terminate request: %w", err)
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("mcp: terminating session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mcp: terminate session: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Notifications opens a server-initiated SSE channel via GET on the same
+// endpoint, used for out-of-band notifications such as
+// "notifications/tools/list_changed". The returned channel is closed when
+// the stream ends, ctx is canceled, or an error occurs; the caller should
+// drain it.
+func (c *Client) Notifications(ctx context.Context) (<-chan Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: building notifications request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.applySessionHeader(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: opening notification channel: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("mcp: opening notification channel: unexpected status %d", resp.StatusCode)
+	}
+
+	notifications := make(chan Request)
+	go func() {
+		defer resp.Body.Close()
+		defer close(notifications)
+		readSSE(resp.Body, func(data []byte) bool {
+			var notification Request
+			if err := json.Unmarshal(data, &notification); err != nil {
+				return true
+			}
+			select {
+			case notifications <- notification:
+			case <-ctx.Done():
+				return false
+			}
+			return true
+		})
+	}()
+
+	return notifications, nil
+}
+
+// notify sends a JSON-RPC notification (no ID, no response expected).
+func (c *Client) notify(ctx context.Context, method string, params interface{}) error {
+	request := Request{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}
+	_, err := c.send(ctx, request)
+	return err
+}
+
+// call sends a JSON-RPC request and waits for its matching response.
+func (c *Client) call(ctx context.Context, method string, params interface{}) (*Response, error) {
+	request := Request{
+		JSONRPC: "2.0",
+		ID:      c.nextRequestID(),
+		Method:  method,
+		Params:  params,
+	}
+	return c.send(ctx, request)
+}
+
+// send POSTs a single JSON-RPC message to the endpoint and, for requests
+// that expect a reply, blocks until the matching response arrives - either
+// directly as a JSON body or as a frame within an SSE stream. It aborts
+// early if ctx is canceled, if the write deadline elapses before the
+// response headers arrive, or if the read deadline elapses while an SSE
+// stream is being read; decoding a plain JSON body is not bound by the
+// read deadline.
+func (c *Client) send(ctx context.Context, request Request) (*Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	c.applySessionHeader(httpReq)
+
+	type doResult struct {
+		resp *http.Response
+		err  error
+	}
+	doCh := make(chan doResult, 1)
+	go func() {
+		resp, err := c.HTTPClient.Do(httpReq)
+		doCh <- doResult{resp, err}
+	}()
+
+	var resp *http.Response
+	select {
+	case res := <-doCh:
+		if res.err != nil {
+			return nil, fmt.Errorf("sending request: %w", res.err)
+		}
+		resp = res.resp
+	case <-c.writeCancel():
+		return nil, fmt.Errorf("mcp: write deadline exceeded")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer resp.Body.Close()
+
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		c.mu.Lock()
+		c.sessionID = sessionID
+		c.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted && request.ID == 0 {
+		// A notification was acknowledged; there is no response to read.
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		var mcpResp Response
+		if err := json.NewDecoder(resp.Body).Decode(&mcpResp); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+		return &mcpResp, nil
+
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		return c.readResponseStream(ctx, resp.Body, request.ID)
+
+	default:
+		return nil, fmt.Errorf("unexpected content-type %q", contentType)
+	}
+}
+
+// readResponseStream consumes an SSE stream of JSON-RPC messages on a
+// background goroutine, skipping any notifications or unrelated responses
+// until the one matching wantID arrives, ctx is canceled, or the read
+// deadline elapses (send's deferred resp.Body.Close then closes body,
+// unblocking the goroutine).
+func (c *Client) readResponseStream(ctx context.Context, body io.Reader, wantID int) (*Response, error) {
+	type result struct {
+		resp *Response
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := parseResponseStream(body, wantID)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("reading event stream: %w", res.err)
+		}
+		return res.resp, nil
+	case <-c.readCancel():
+		return nil, fmt.Errorf("mcp: read deadline exceeded")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// parseResponseStream scans data frames off r until one decodes to a
+// Response with a matching ID.
+func parseResponseStream(r io.Reader, wantID int) (*Response, error) {
+	var final *Response
+	err := readSSE(r, func(data []byte) bool {
+		var resp Response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return true
+		}
+		if resp.ID != wantID {
+			// A server-to-client request or an unrelated response; keep
+			// reading until our own response arrives.
+			return true
+		}
+		final = &resp
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if final == nil {
+		return nil, fmt.Errorf("event stream closed before a matching response arrived")
+	}
+	return final, nil
+}
+
+// readSSE parses "event:"/"data:" framed Server-Sent Events from r, calling
+// onData with the accumulated data payload of each event. Parsing stops
+// when r is exhausted or onData returns false.
+func readSSE(r io.Reader, onData func(data []byte) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var data bytes.Buffer
+	flush := func() bool {
+		if data.Len() == 0 {
+			return true
+		}
+		payload := bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+		cont := onData(payload)
+		data.Reset()
+		return cont
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if !flush() {
+				return nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			data.WriteByte('\n')
+		case strings.HasPrefix(line, "event:"), strings.HasPrefix(line, "id:"), strings.HasPrefix(line, ":"):
+			// Event name, event ID, and comment lines don't affect the
+			// accumulated data payload.
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+func (c *Client) applySessionHeader(req *http.Request) {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.mu.Unlock()
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+}
+
+func (c *Client) nextRequestID() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.nextID
+	c.nextID++
+	return id
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// deadlineTimer wires SetDeadline/SetReadDeadline/SetWriteDeadline onto a
+// pair of cancellation channels, following the pattern used by
+// gVisor's netstack gonet package: each deadline is backed by a
+// channel that a time.AfterFunc timer closes when the deadline elapses,
+// so in-flight operations can select on it alongside their normal
+// completion path.
+type deadlineTimer struct {
+	mu sync.Mutex
+
+	readTimer  *time.Timer
+	writeTimer *time.Timer
+
+	readCancelCh  chan struct{}
+	writeCancelCh chan struct{}
+}
+
+// init must be called before the deadlineTimer is used.
+func (d *deadlineTimer) init() {
+	d.readCancelCh = make(chan struct{})
+	d.writeCancelCh = make(chan struct{})
+}
+
+// readCancel returns the channel that closes when the read deadline elapses.
+func (d *deadlineTimer) readCancel() <-chan struct{} {
+	d.mu.Lock()
+	c := d.readCancelCh
+	d.mu.Unlock()
+	return c
+}
+
+// writeCancel returns the channel that closes when the write deadline
+// elapses.
+func (d *deadlineTimer) writeCancel() <-chan struct{} {
+	d.mu.Lock()
+	c := d.writeCancelCh
+	d.mu.Unlock()
+	return c
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (d *deadlineTimer) SetDeadline(t time.Time) {
+	d.SetReadDeadline(t)
+	d.SetWriteDeadline(t)
+}
+
+// SetReadDeadline arms (or disarms, with a zero time) the timer backing
+// readCancel.
+func (d *deadlineTimer) SetReadDeadline(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.readTimer = setDeadlineTimer(d.readTimer, &d.readCancelCh, t)
+}
+
+// SetWriteDeadline arms (or disarms, with a zero time) the timer backing
+// writeCancel.
+func (d *deadlineTimer) SetWriteDeadline(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.writeTimer = setDeadlineTimer(d.writeTimer, &d.writeCancelCh, t)
+}
+
+// setDeadlineTimer stops the previous timer, replaces *cancelCh with a
+// fresh channel if the old one had already fired, and - unless t is the
+// zero time - arms a new timer that closes the captured channel when t
+// arrives. Callers must hold the deadlineTimer's mutex.
+func setDeadlineTimer(timer *time.Timer, cancelCh *chan struct{}, t time.Time) *time.Timer {
+	if timer != nil && !timer.Stop() {
+		// The timer already fired, and its func may not have closed the
+		// channel yet; don't let that close land on the new deadline.
+		*cancelCh = make(chan struct{})
+	}
+
+	select {
+	case <-*cancelCh:
+		*cancelCh = make(chan struct{})
+	default:
+	}
+
+	if t.IsZero() {
+		return nil
+	}
+
+	ch := *cancelCh
+	return time.AfterFunc(time.Until(t), func() {
+		close(ch)
+	})
+}
@@ -0,0 +1,286 @@
+// Package router picks which MCP tool should answer a prompt and builds
+// arguments for it that conform to the tool's declared JSON Schema,
+// replacing a hardcoded "always call tools[0]" choice.
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"somethingsoftware/LowBandwidth.Online/ai/mcp"
+)
+
+// Property describes one entry of a tool's inputSchema.properties.
+type Property struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// Schema is the subset of JSON Schema MCP tools declare their arguments
+// with.
+type Schema struct {
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties"`
+	Required   []string            `json:"required"`
+}
+
+// Tool is a parsed entry from the gateway's tools/list response.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema Schema
+}
+
+// ToolChoice is the router's decision: which tool to call, with what
+// arguments, and how confident it is in the match.
+type ToolChoice struct {
+	Name       string
+	Arguments  map[string]interface{}
+	Confidence float64
+}
+
+// Selector picks a tool for a prompt out of a catalog, reporting its
+// confidence in [0, 1]. The default, KeywordSelector, matches prompt
+// words against tool descriptions; callers can swap in an
+// embedding- or LLM-based selector instead.
+type Selector func(prompt string, tools []Tool) (Tool, float64, error)
+
+// Router caches a gateway's tool catalog and routes prompts to the best
+// matching tool.
+type Router struct {
+	client   *mcp.Client
+	selector Selector
+
+	mu    sync.Mutex
+	tools []Tool
+}
+
+// NewRouter creates a Router over client's tool catalog. A nil selector
+// defaults to KeywordSelector.
+func NewRouter(client *mcp.Client, selector Selector) *Router {
+	if selector == nil {
+		selector = KeywordSelector
+	}
+	return &Router{client: client, selector: selector}
+}
+
+// Watch subscribes to the gateway's notification channel and invalidates
+// the cached tool catalog on "notifications/tools/list_changed". It
+// returns once the subscription is established; invalidation happens on
+// a background goroutine for the lifetime of ctx.
+func (r *Router) Watch(ctx context.Context) error {
+	notifications, err := r.client.Notifications(ctx)
+	if err != nil {
+		return fmt.Errorf("router: subscribing to notifications: %w", err)
+	}
+
+	go func() {
+		for notification := range notifications {
+			if notification.Method == "notifications/tools/list_changed" {
+				r.invalidate()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Route picks a tool for prompt and builds arguments for it, filling in
+// model wherever the tool's schema declares a field for it.
+func (r *Router) Route(ctx context.Context, prompt, model string) (*ToolChoice, error) {
+	tools, err := r.cachedTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) == 0 {
+		return nil, fmt.Errorf("router: no tools available")
+	}
+
+	tool, confidence, err := r.selector(prompt, tools)
+	if err != nil {
+		return nil, fmt.Errorf("router: selecting a tool: %w", err)
+	}
+
+	arguments, err := buildArguments(tool, prompt, model)
+	if err != nil {
+		return nil, fmt.Errorf("router: building arguments for %s: %w", tool.Name, err)
+	}
+
+	return &ToolChoice{Name: tool.Name, Arguments: arguments, Confidence: confidence}, nil
+}
+
+// cachedTools returns the cached tool catalog, fetching and parsing it
+// on a cache miss.
+func (r *Router) cachedTools(ctx context.Context) ([]Tool, error) {
+	r.mu.Lock()
+	if r.tools != nil {
+		tools := r.tools
+		r.mu.Unlock()
+		return tools, nil
+	}
+	r.mu.Unlock()
+
+	raw, err := r.client.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("router: listing tools: %w", err)
+	}
+
+	tools := make([]Tool, 0, len(raw))
+	for _, entry := range raw {
+		tool, err := parseTool(entry)
+		if err != nil {
+			return nil, fmt.Errorf("router: %w", err)
+		}
+		tools = append(tools, tool)
+	}
+
+	r.mu.Lock()
+	r.tools = tools
+	r.mu.Unlock()
+
+	return tools, nil
+}
+
+func (r *Router) invalidate() {
+	r.mu.Lock()
+	r.tools = nil
+	r.mu.Unlock()
+}
+
+// parseTool decodes one tools/list entry, which arrives as a
+// map[string]interface{}, into a Tool with its inputSchema parsed.
+func parseTool(entry interface{}) (Tool, error) {
+	entryMap, ok := entry.(map[string]interface{})
+	if !ok {
+		return Tool{}, fmt.Errorf("unexpected tool shape: %v", entry)
+	}
+
+	name, ok := entryMap["name"].(string)
+	if !ok {
+		return Tool{}, fmt.Errorf("tool missing a name: %v", entryMap)
+	}
+	description, _ := entryMap["description"].(string)
+
+	var schema Schema
+	if rawSchema, ok := entryMap["inputSchema"]; ok {
+		data, err := json.Marshal(rawSchema)
+		if err != nil {
+			return Tool{}, fmt.Errorf("tool %s: marshaling inputSchema: %w", name, err)
+		}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return Tool{}, fmt.Errorf("tool %s: parsing inputSchema: %w", name, err)
+		}
+	}
+
+	return Tool{Name: name, Description: description, InputSchema: schema}, nil
+}
+
+// KeywordSelector scores each tool by how many of its description's
+// words appear in the prompt, picking the highest scorer. Ties go to the
+// first tool encountered.
+func KeywordSelector(prompt string, tools []Tool) (Tool, float64, error) {
+	if len(tools) == 0 {
+		return Tool{}, 0, fmt.Errorf("no tools to select from")
+	}
+
+	normalizedPrompt := strings.ToLower(prompt)
+
+	best := tools[0]
+	bestScore, bestWords := 0, 0
+
+	for _, tool := range tools {
+		words := strings.Fields(strings.ToLower(tool.Description))
+		score := 0
+		for _, word := range words {
+			word = strings.Trim(word, ".,!?:;")
+			if word != "" && strings.Contains(normalizedPrompt, word) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore, bestWords = tool, score, len(words)
+		}
+	}
+
+	if bestScore == 0 {
+		// No keyword overlap with any tool description; fall back to the
+		// first tool, but flag it with zero confidence.
+		return tools[0], 0, nil
+	}
+
+	confidence := float64(bestScore) / float64(bestWords)
+	if confidence > 1 {
+		confidence = 1
+	}
+	return best, confidence, nil
+}
+
+// buildArguments fills in tool's declared properties from the known
+// values derived from prompt and model, filling string properties with the
+// value and array properties with a one-element array; properties of any
+// other type are left unfilled. It fails if a required field can't be
+// filled.
+func buildArguments(tool Tool, prompt, model string) (map[string]interface{}, error) {
+	known := knownValues(prompt, model)
+	arguments := make(map[string]interface{}, len(tool.InputSchema.Properties))
+
+	required := make(map[string]bool, len(tool.InputSchema.Required))
+	for _, name := range tool.InputSchema.Required {
+		required[name] = true
+	}
+
+	for name, property := range tool.InputSchema.Properties {
+		value, ok := fillProperty(name, property, known)
+		if ok {
+			arguments[name] = value
+			continue
+		}
+		if required[name] {
+			return nil, fmt.Errorf("required field %q cannot be filled from the prompt or model", name)
+		}
+	}
+
+	return arguments, nil
+}
+
+// promptFieldNames are the property names commonly used by MCP tools for
+// the free-text portion of their input; all of them resolve to the
+// prompt itself.
+var promptFieldNames = map[string]bool{
+	"prompt": true, "query": true, "question": true, "input": true, "text": true,
+}
+
+// knownValues maps the field names a tool's schema might declare to the
+// values the router already has on hand - the prompt itself, plus the
+// model passed to Route, so a tool that requires one of those fields is
+// still routable.
+func knownValues(prompt, model string) map[string]string {
+	values := make(map[string]string, len(promptFieldNames)+1)
+	for field := range promptFieldNames {
+		values[field] = prompt
+	}
+	values["model"] = model
+	return values
+}
+
+// fillProperty coerces a known value into property's declared type if
+// name matches one the router has on hand.
+func fillProperty(name string, property Property, known map[string]string) (interface{}, bool) {
+	value, ok := known[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+
+	switch property.Type {
+	case "", "string":
+		return value, true
+	case "array":
+		return []interface{}{value}, true
+	default:
+		// Numeric/boolean/object fields can't be coerced from free text.
+		return nil, false
+	}
+}
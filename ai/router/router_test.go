@@ -0,0 +1,141 @@
+package router
+
+import "testing"
+
+func TestKeywordSelectorPicksBestMatchingDescription(t *testing.T) {
+	tools := []Tool{
+		{Name: "weather", Description: "Look up the current weather forecast for a city"},
+		{Name: "calculator", Description: "Evaluate a math expression"},
+	}
+
+	tool, confidence, err := KeywordSelector("what's the weather forecast in Boston?", tools)
+	if err != nil {
+		t.Fatalf("KeywordSelector: %v", err)
+	}
+	if tool.Name != "weather" {
+		t.Errorf("selected %q, want %q", tool.Name, "weather")
+	}
+	if confidence <= 0 {
+		t.Errorf("confidence = %v, want > 0 for a matching prompt", confidence)
+	}
+}
+
+func TestKeywordSelectorFallsBackToFirstToolOnNoOverlap(t *testing.T) {
+	tools := []Tool{
+		{Name: "weather", Description: "Look up current forecast conditions"},
+		{Name: "calculator", Description: "Evaluate arithmetic expressions"},
+	}
+
+	tool, confidence, err := KeywordSelector("recommend a birthday gift idea", tools)
+	if err != nil {
+		t.Fatalf("KeywordSelector: %v", err)
+	}
+	if tool.Name != tools[0].Name {
+		t.Errorf("selected %q, want fallback to first tool %q", tool.Name, tools[0].Name)
+	}
+	if confidence != 0 {
+		t.Errorf("confidence = %v, want 0 for a fallback match", confidence)
+	}
+}
+
+func TestKeywordSelectorRejectsEmptyCatalog(t *testing.T) {
+	if _, _, err := KeywordSelector("anything", nil); err == nil {
+		t.Error("KeywordSelector should reject an empty tool catalog")
+	}
+}
+
+func TestBuildArgumentsFillsPromptAndModelFields(t *testing.T) {
+	tool := Tool{
+		Name: "ask",
+		InputSchema: Schema{
+			Properties: map[string]Property{
+				"prompt": {Type: "string"},
+				"model":  {Type: "string"},
+			},
+			Required: []string{"prompt", "model"},
+		},
+	}
+
+	arguments, err := buildArguments(tool, "what's the weather?", "mistral")
+	if err != nil {
+		t.Fatalf("buildArguments: %v", err)
+	}
+	if arguments["prompt"] != "what's the weather?" {
+		t.Errorf("prompt = %v, want the original prompt", arguments["prompt"])
+	}
+	if arguments["model"] != "mistral" {
+		t.Errorf("model = %v, want %q", arguments["model"], "mistral")
+	}
+}
+
+func TestBuildArgumentsErrorsOnUnfillableRequiredField(t *testing.T) {
+	tool := Tool{
+		Name: "ask",
+		InputSchema: Schema{
+			Properties: map[string]Property{
+				"prompt":     {Type: "string"},
+				"max_tokens": {Type: "number"},
+			},
+			Required: []string{"prompt", "max_tokens"},
+		},
+	}
+
+	if _, err := buildArguments(tool, "hello", "mistral"); err == nil {
+		t.Error("buildArguments should fail when a required numeric field can't be filled from the prompt")
+	}
+}
+
+func TestBuildArgumentsOmitsUnfillableOptionalField(t *testing.T) {
+	tool := Tool{
+		Name: "ask",
+		InputSchema: Schema{
+			Properties: map[string]Property{
+				"prompt":     {Type: "string"},
+				"max_tokens": {Type: "number"},
+			},
+			Required: []string{"prompt"},
+		},
+	}
+
+	arguments, err := buildArguments(tool, "hello", "mistral")
+	if err != nil {
+		t.Fatalf("buildArguments: %v", err)
+	}
+	if _, ok := arguments["max_tokens"]; ok {
+		t.Error("buildArguments should omit an unfillable optional field rather than guessing a value")
+	}
+}
+
+func TestParseToolParsesInputSchema(t *testing.T) {
+	entry := map[string]interface{}{
+		"name":        "ask",
+		"description": "Answer a question",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"prompt": map[string]interface{}{"type": "string", "description": "the question"},
+			},
+			"required": []interface{}{"prompt"},
+		},
+	}
+
+	tool, err := parseTool(entry)
+	if err != nil {
+		t.Fatalf("parseTool: %v", err)
+	}
+	if tool.Name != "ask" {
+		t.Errorf("Name = %q, want %q", tool.Name, "ask")
+	}
+	if tool.InputSchema.Properties["prompt"].Type != "string" {
+		t.Errorf("prompt property type = %q, want %q", tool.InputSchema.Properties["prompt"].Type, "string")
+	}
+	if len(tool.InputSchema.Required) != 1 || tool.InputSchema.Required[0] != "prompt" {
+		t.Errorf("Required = %v, want [prompt]", tool.InputSchema.Required)
+	}
+}
+
+func TestParseToolRejectsMissingName(t *testing.T) {
+	if _, err := parseTool(map[string]interface{}{"description": "no name"}); err == nil {
+		t.Error("parseTool should reject an entry with no name")
+	}
+}
@@ -0,0 +1,66 @@
+// Package api exposes ai.Client over HTTP as a single POST /ai endpoint,
+// returning either a JSON object or an SSE stream depending on the
+// client's Accept header.
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"somethingsoftware/LowBandwidth.Online/ai"
+)
+
+// Request is the body POSTed to /ai.
+type Request struct {
+	Prompt string `json:"prompt"`
+	Model  string `json:"model,omitempty"`
+}
+
+// Response is a single AI answer.
+type Response struct {
+	Response string `json:"response"`
+}
+
+// Handler answers a single AI request. Implementations may be wrapped in
+// Middleware for logging, auth, or request-ID propagation.
+type Handler interface {
+	Handle(ctx context.Context, req Request) (Response, error)
+}
+
+// HandlerFunc adapts a plain function to a Handler.
+type HandlerFunc func(ctx context.Context, req Request) (Response, error)
+
+// Handle implements Handler.
+func (f HandlerFunc) Handle(ctx context.Context, req Request) (Response, error) {
+	return f(ctx, req)
+}
+
+// Middleware wraps a Handler with additional behavior, composing around it
+// the same way http.Handler middleware does.
+type Middleware func(Handler) Handler
+
+// chain applies middleware in order, so the first entry runs outermost.
+func chain(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// NewAIHandler adapts an ai.Client into a Handler.
+func NewAIHandler(client *ai.Client) Handler {
+	return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		if req.Prompt == "" {
+			return Response{}, fmt.Errorf("prompt is required")
+		}
+		if req.Model == "" {
+			return Response{}, fmt.Errorf("model is required")
+		}
+
+		text, err := client.AIFunction(ctx, req.Prompt, req.Model)
+		if err != nil {
+			return Response{}, err
+		}
+		return Response{Response: text}, nil
+	})
+}
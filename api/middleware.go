@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+)
+
+// contextKey namespaces values api stores on a request's context.
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// RequestIDFromContext returns the request ID the Server attached to ctx,
+// if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// withRequestID attaches id, the request's X-Request-Id header or a
+// freshly generated one, to ctx. The Server calls this itself (rather
+// than exposing it as a Middleware) since it needs the raw *http.Request
+// the Handler abstraction deliberately hides.
+func withRequestID(ctx context.Context, r *http.Request) context.Context {
+	id := r.Header.Get("X-Request-Id")
+	if id == "" {
+		id = newRequestID()
+	}
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// LoggingMiddleware logs each request's latency and outcome to logger.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			start := time.Now()
+			resp, err := next.Handle(ctx, req)
+
+			id, _ := RequestIDFromContext(ctx)
+			if err != nil {
+				logger.Printf("request_id=%s model=%s duration=%s error=%v", id, req.Model, time.Since(start), err)
+			} else {
+				logger.Printf("request_id=%s model=%s duration=%s", id, req.Model, time.Since(start))
+			}
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Resolver picks the backend model for a request, given the request and
+// whatever model the JSON body already carried. It reports false when it
+// has no opinion, so callers can fall through to the next resolver.
+type Resolver func(r *http.Request, bodyModel string) (model string, ok bool)
+
+// ResolverChain tries each Resolver in order and uses the first that
+// matches, letting operators front multiple models behind one endpoint by
+// JSON body, path prefix, or Host header.
+type ResolverChain []Resolver
+
+// Resolve runs the chain, returning an error if no resolver matches.
+func (c ResolverChain) Resolve(r *http.Request, bodyModel string) (string, error) {
+	for _, resolve := range c {
+		if model, ok := resolve(r, bodyModel); ok {
+			return model, nil
+		}
+	}
+	return "", fmt.Errorf("api: could not resolve a model from the request")
+}
+
+// DefaultResolvers tries the JSON body first, then a /ai/{model}/... path
+// prefix, then the Host header.
+var DefaultResolvers = ResolverChain{
+	ResolveFromBody,
+	ResolveFromPath,
+	ResolveFromHost,
+}
+
+// ResolveFromBody uses the model the client already put in the JSON body.
+func ResolveFromBody(_ *http.Request, bodyModel string) (string, bool) {
+	return bodyModel, bodyModel != ""
+}
+
+// ResolveFromPath reads the model out of a /ai/{model}/... path prefix.
+func ResolveFromPath(r *http.Request, _ string) (string, bool) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) >= 2 && segments[0] == "ai" && segments[1] != "" {
+		return segments[1], true
+	}
+	return "", false
+}
+
+// ResolveFromHost reads the model out of the leading label of the Host
+// header, e.g. "mistral.ai.example.com" resolves to "mistral".
+func ResolveFromHost(r *http.Request, _ string) (string, bool) {
+	host := r.Host
+	if colon := strings.IndexByte(host, ':'); colon != -1 {
+		host = host[:colon]
+	}
+	label, _, found := strings.Cut(host, ".")
+	if !found || label == "" {
+		return "", false
+	}
+	return label, true
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"somethingsoftware/LowBandwidth.Online/pow"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Addr is the address to listen on. A "unix:" prefix listens on a
+	// unix socket at the given path instead of TCP, e.g. "unix:/tmp/ai.sock".
+	Addr string
+
+	// MaxRecvSize caps the size of a request body in bytes. Zero means
+	// no limit.
+	MaxRecvSize int64
+
+	// Resolver picks which backend model answers a request. Defaults to
+	// DefaultResolvers.
+	Resolver ResolverChain
+
+	// Middleware wraps the Handler, outermost entry first - slots for
+	// logging, auth, and request-ID propagation.
+	Middleware []Middleware
+
+	// PoW, when set, requires a solved proof-of-work challenge (in an
+	// X-Pow header) on every POST /ai, and exposes GET /pow/challenge to
+	// issue one.
+	PoW *pow.Manager
+	// PoWDifficulty picks the difficulty for a new challenge, so it can
+	// be tuned per-route and per-client-IP. Defaults to a flat 20 bits.
+	PoWDifficulty func(r *http.Request) int
+	// PoWChallengeTTL is how long an issued challenge remains solvable.
+	// Defaults to 5 minutes.
+	PoWChallengeTTL time.Duration
+}
+
+const (
+	defaultPoWDifficulty   = 20
+	defaultPoWChallengeTTL = 5 * time.Minute
+)
+
+// Server exposes a Handler as an HTTP/JSON service on POST /ai (and
+// /ai/{model}/...).
+type Server struct {
+	opts    Options
+	handler Handler
+	mux     *http.ServeMux
+}
+
+// NewServer builds a Server serving handler, wrapped in opts.Middleware.
+func NewServer(handler Handler, opts Options) *Server {
+	if opts.Resolver == nil {
+		opts.Resolver = DefaultResolvers
+	}
+	if opts.PoWDifficulty == nil {
+		opts.PoWDifficulty = func(*http.Request) int { return defaultPoWDifficulty }
+	}
+	if opts.PoWChallengeTTL == 0 {
+		opts.PoWChallengeTTL = defaultPoWChallengeTTL
+	}
+
+	s := &Server{
+		opts:    opts,
+		handler: chain(handler, opts.Middleware),
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/ai", s.handleAI)
+	s.mux.HandleFunc("/ai/", s.handleAI)
+	if s.opts.PoW != nil {
+		s.mux.HandleFunc("/pow/challenge", s.handleChallenge)
+	}
+
+	return s
+}
+
+// ListenAndServe listens on opts.Addr (TCP, or a unix socket for a
+// "unix:" address) and serves until the listener errors.
+func (s *Server) ListenAndServe() error {
+	network, address := "tcp", s.opts.Addr
+	if rest, ok := strings.CutPrefix(s.opts.Addr, "unix:"); ok {
+		network, address = "unix", rest
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("api: listening on %s: %w", s.opts.Addr, err)
+	}
+	return http.Serve(listener, s)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleChallenge issues a fresh proof-of-work challenge.
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	difficulty := s.opts.PoWDifficulty(r)
+	challenge, err := s.opts.PoW.NewChallenge(r.Context(), difficulty, s.opts.PoWChallengeTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// handleAI answers POST /ai and POST /ai/{model}/..., gated by a solved
+// PoW challenge when one is configured.
+func (s *Server) handleAI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.opts.PoW != nil && !s.checkPoW(w, r) {
+		return
+	}
+
+	body := r.Body
+	if s.opts.MaxRecvSize > 0 {
+		body = http.MaxBytesReader(w, r.Body, s.opts.MaxRecvSize)
+	}
+
+	var req Request
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	model, err := s.opts.Resolver.Resolve(r, req.Model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Model = model
+
+	ctx := withRequestID(r.Context(), r)
+	resp, err := s.handler.Handle(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		writeEventStream(w, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkPoW verifies the X-Pow header carries a base64-JSON pow.Solution
+// that satisfies s.opts.PoW. On failure it writes the error response
+// itself and returns false.
+func (s *Server) checkPoW(w http.ResponseWriter, r *http.Request) bool {
+	header := r.Header.Get("X-Pow")
+	if header == "" {
+		http.Error(w, "missing X-Pow header; GET /pow/challenge first", http.StatusPaymentRequired)
+		return false
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		http.Error(w, "malformed X-Pow header", http.StatusBadRequest)
+		return false
+	}
+
+	var solution pow.Solution
+	if err := json.Unmarshal(raw, &solution); err != nil {
+		http.Error(w, "malformed X-Pow header", http.StatusBadRequest)
+		return false
+	}
+
+	if err := s.opts.PoW.Check(r.Context(), solution); err != nil {
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+		return false
+	}
+
+	return true
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeEventStream emits resp as a single SSE "message" event. The
+// handler only ever produces one answer today, but framing it as a
+// stream now means callers built against SSE don't need to change when
+// partial/streamed answers land.
+func writeEventStream(w http.ResponseWriter, resp Response) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,231 @@
+// Package history persists prompts, tool calls, and responses in Postgres
+// so that repeated or near-duplicate questions can be answered from a
+// local cache instead of round-tripping to the MCP gateway - the
+// bandwidth saving this project is named for.
+package history
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// schema creates the tables history needs if they don't already exist.
+// Conversations are looked up by prompt_hash, and full-text searched via a
+// generated tsvector over the prompt and response.
+const schema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id          BIGSERIAL PRIMARY KEY,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	model       TEXT NOT NULL,
+	prompt_hash TEXT NOT NULL,
+	prompt      TEXT NOT NULL,
+	response    TEXT NOT NULL,
+	tool_calls  JSONB NOT NULL DEFAULT '[]',
+	search      TSVECTOR GENERATED ALWAYS AS (
+		to_tsvector('english', coalesce(prompt, '') || ' ' || coalesce(response, ''))
+	) STORED
+);
+CREATE INDEX IF NOT EXISTS conversations_prompt_hash_idx ON conversations (prompt_hash, created_at DESC);
+CREATE INDEX IF NOT EXISTS conversations_search_idx ON conversations USING GIN (search);
+
+CREATE TABLE IF NOT EXISTS tool_invocations (
+	id              BIGSERIAL PRIMARY KEY,
+	conversation_id BIGINT NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+	tool_name       TEXT NOT NULL,
+	arguments       JSONB NOT NULL DEFAULT '{}',
+	result          JSONB NOT NULL DEFAULT 'null',
+	latency_ms      BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS tool_invocations_conversation_id_idx ON tool_invocations (conversation_id);
+`
+
+// ToolCall is a tool invocation summarized onto its conversation row.
+type ToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+	Result    json.RawMessage `json:"result"`
+}
+
+// Conversation is a single prompt/response exchange.
+type Conversation struct {
+	ID         int64
+	CreatedAt  time.Time
+	Model      string
+	PromptHash string
+	Prompt     string
+	Response   string
+	ToolCalls  []ToolCall
+}
+
+// Store persists and looks up conversation history in Postgres.
+type Store struct {
+	DB *sql.DB
+	// TTL is how long a cached response is considered fresh. A zero TTL
+	// disables caching: Lookup always reports a miss.
+	TTL time.Duration
+}
+
+// NewStore creates a Store backed by db, caching responses for ttl.
+func NewStore(db *sql.DB, ttl time.Duration) *Store {
+	return &Store{DB: db, TTL: ttl}
+}
+
+// Migrate creates the history tables if they don't already exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	if _, err := s.DB.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("history: migrating schema: %w", err)
+	}
+	return nil
+}
+
+// HashPrompt computes a stable cache key for a (model, prompt) pair.
+func HashPrompt(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + normalizePrompt(prompt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizePrompt collapses whitespace and case differences so that
+// near-duplicate prompts hash identically.
+func normalizePrompt(prompt string) string {
+	return strings.Join(strings.Fields(strings.ToLower(prompt)), " ")
+}
+
+// Lookup returns the most recent conversation for (model, prompt) if one
+// exists and is within the Store's TTL.
+func (s *Store) Lookup(ctx context.Context, model, prompt string) (*Conversation, bool, error) {
+	if s.TTL <= 0 {
+		return nil, false, nil
+	}
+
+	hash := HashPrompt(model, prompt)
+	row := s.DB.QueryRowContext(ctx, `
+		SELECT id, created_at, model, prompt_hash, prompt, response, tool_calls
+		FROM conversations
+		WHERE prompt_hash = $1 AND created_at > $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, hash, time.Now().Add(-s.TTL))
+
+	conversation, err := scanConversation(row)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("history: looking up prompt: %w", err)
+	}
+	return conversation, true, nil
+}
+
+// Save writes a new conversation row and returns it with its assigned ID.
+func (s *Store) Save(ctx context.Context, model, prompt, response string, toolCalls []ToolCall) (*Conversation, error) {
+	if toolCalls == nil {
+		toolCalls = []ToolCall{}
+	}
+	toolCallsJSON, err := json.Marshal(toolCalls)
+	if err != nil {
+		return nil, fmt.Errorf("history: marshaling tool calls: %w", err)
+	}
+
+	conversation := &Conversation{
+		Model:      model,
+		PromptHash: HashPrompt(model, prompt),
+		Prompt:     prompt,
+		Response:   response,
+		ToolCalls:  toolCalls,
+	}
+
+	err = s.DB.QueryRowContext(ctx, `
+		INSERT INTO conversations (model, prompt_hash, prompt, response, tool_calls)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, model, conversation.PromptHash, prompt, response, toolCallsJSON).Scan(&conversation.ID, &conversation.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("history: saving conversation: %w", err)
+	}
+	return conversation, nil
+}
+
+// RecordToolInvocation logs a single tool call made while answering a
+// conversation.
+func (s *Store) RecordToolInvocation(ctx context.Context, conversationID int64, toolName string, arguments, result interface{}, latencyMs int64) error {
+	argumentsJSON, err := json.Marshal(arguments)
+	if err != nil {
+		return fmt.Errorf("history: marshaling tool arguments: %w", err)
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("history: marshaling tool result: %w", err)
+	}
+
+	_, err = s.DB.ExecContext(ctx, `
+		INSERT INTO tool_invocations (conversation_id, tool_name, arguments, result, latency_ms)
+		VALUES ($1, $2, $3, $4, $5)
+	`, conversationID, toolName, argumentsJSON, resultJSON, latencyMs)
+	if err != nil {
+		return fmt.Errorf("history: recording tool invocation: %w", err)
+	}
+	return nil
+}
+
+// Search runs a full-text search over prior prompts and responses using
+// Postgres's tsvector/tsquery, most recent first.
+func (s *Store) Search(ctx context.Context, query string) ([]Conversation, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, created_at, model, prompt_hash, prompt, response, tool_calls
+		FROM conversations
+		WHERE search @@ plainto_tsquery('english', $1)
+		ORDER BY created_at DESC
+	`, query)
+	if err != nil {
+		return nil, fmt.Errorf("history: searching: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Conversation
+	for rows.Next() {
+		conversation, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("history: scanning search result: %w", err)
+		}
+		results = append(results, *conversation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("history: searching: %w", err)
+	}
+	return results, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanConversation(row rowScanner) (*Conversation, error) {
+	var (
+		conversation  Conversation
+		toolCallsJSON []byte
+	)
+	if err := row.Scan(
+		&conversation.ID,
+		&conversation.CreatedAt,
+		&conversation.Model,
+		&conversation.PromptHash,
+		&conversation.Prompt,
+		&conversation.Response,
+		&toolCallsJSON,
+	); err != nil {
+		return nil, err
+	}
+	if len(toolCallsJSON) > 0 {
+		if err := json.Unmarshal(toolCallsJSON, &conversation.ToolCalls); err != nil {
+			return nil, fmt.Errorf("unmarshaling tool calls: %w", err)
+		}
+	}
+	return &conversation, nil
+}
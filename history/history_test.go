@@ -0,0 +1,45 @@
+package history
+
+import "testing"
+
+func TestHashPromptNormalizesWhitespaceAndCase(t *testing.T) {
+	variants := []string{
+		"What is the weather?",
+		"what is the weather?",
+		"  what   is the weather?  ",
+		"WHAT IS THE WEATHER?",
+	}
+
+	want := HashPrompt("mistral", variants[0])
+	for _, prompt := range variants[1:] {
+		if got := HashPrompt("mistral", prompt); got != want {
+			t.Errorf("HashPrompt(%q) = %s, want %s (same as %q)", prompt, got, want, variants[0])
+		}
+	}
+}
+
+func TestHashPromptDistinguishesModelAndContent(t *testing.T) {
+	base := HashPrompt("mistral", "what is the weather?")
+
+	if got := HashPrompt("gpt-4", "what is the weather?"); got == base {
+		t.Errorf("HashPrompt with a different model collided with %s", base)
+	}
+	if got := HashPrompt("mistral", "what is the forecast?"); got == base {
+		t.Errorf("HashPrompt with a different prompt collided with %s", base)
+	}
+}
+
+func TestNormalizePrompt(t *testing.T) {
+	cases := map[string]string{
+		"What is the weather?":    "what is the weather?",
+		"  multiple   spaces  ":   "multiple spaces",
+		"\tTabs\nand\nnewlines\t": "tabs and newlines",
+		"already normalized":      "already normalized",
+	}
+
+	for input, want := range cases {
+		if got := normalizePrompt(input); got != want {
+			t.Errorf("normalizePrompt(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
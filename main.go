@@ -1,23 +1,38 @@
 package main
 
 import (
-	_ "github.com/joho/godotenv"
+	"context"
 	"log"
-	_ "somethingsoftware/LowBandwidth.Online/db"
+	"time"
+
+	"github.com/joho/godotenv"
+
 	"somethingsoftware/LowBandwidth.Online/ai"
+	"somethingsoftware/LowBandwidth.Online/db"
+	"somethingsoftware/LowBandwidth.Online/history"
 )
 
 func main() {
-	// if err := godotenv.Load(".env"); err != nil {
-	// 	log.Fatal("Error loading .env file")
-	// }
-	// db, err := db.NewDB()
-	// if err != nil {
-	// 	log.Fatal("Error connecting to database:", err)
-	// }
-	// defer db.Close()
-	// log.Println("Database connection established successfully")
-	response, err := ai.AIFunction("What is the current weather in New York?", "mistral")
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("No .env file found, continuing with existing environment")
+	}
+
+	var historyStore *history.Store
+	pg, err := db.NewDB()
+	if err != nil {
+		log.Println("Database unavailable, continuing without response caching:", err)
+	} else {
+		defer pg.Close()
+		historyStore = history.NewStore(pg, time.Hour)
+		if err := historyStore.Migrate(context.Background()); err != nil {
+			log.Fatal("Error migrating history schema:", err)
+		}
+	}
+
+	client := ai.NewClient(ai.DefaultGatewayURL, historyStore)
+	defer client.Close(context.Background())
+
+	response, err := client.AIFunction(context.Background(), "What is the current weather in New York?", "mistral")
 	if err != nil {
 		log.Fatal("Error querying AI: ", err)
 	}
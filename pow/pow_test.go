@@ -0,0 +1,148 @@
+package pow
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLeadingZeroBits(t *testing.T) {
+	cases := []struct {
+		sum  []byte
+		want int
+	}{
+		{[]byte{0xff}, 0},
+		{[]byte{0x7f}, 1},
+		{[]byte{0x00, 0xff}, 8},
+		{[]byte{0x00, 0x01}, 15},
+		{[]byte{0x00, 0x00}, 16},
+		{[]byte{0x10}, 3},
+	}
+
+	for _, c := range cases {
+		if got := leadingZeroBits(c.sum); got != c.want {
+			t.Errorf("leadingZeroBits(%08b) = %d, want %d", c.sum, got, c.want)
+		}
+	}
+}
+
+// solve brute-forces a nonce satisfying challenge's difficulty, failing
+// the test if it can't find one quickly - difficulty is kept low enough
+// in these tests that this is fast and deterministic.
+func solve(t *testing.T, challenge *Challenge) string {
+	t.Helper()
+	for nonce := 0; nonce < 1_000_000; nonce++ {
+		candidate := fmt.Sprintf("%d", nonce)
+		sum := sha256.Sum256([]byte(challenge.Seed + candidate))
+		if leadingZeroBits(sum[:]) >= challenge.Difficulty {
+			return candidate
+		}
+	}
+	t.Fatalf("could not find a nonce for difficulty %d in time", challenge.Difficulty)
+	return ""
+}
+
+func TestManagerCheckAcceptsAValidSolution(t *testing.T) {
+	manager := NewManager([]byte("test-secret"), 0)
+	challenge, err := manager.NewChallenge(context.Background(), 8, time.Minute)
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	solution := Solution{Seed: challenge.Seed, Nonce: solve(t, challenge), Signature: challenge.Signature}
+	if err := manager.Check(context.Background(), solution); err != nil {
+		t.Errorf("Check rejected a valid solution: %v", err)
+	}
+}
+
+func TestManagerCheckRejectsReplay(t *testing.T) {
+	manager := NewManager([]byte("test-secret"), 0)
+	challenge, err := manager.NewChallenge(context.Background(), 4, time.Minute)
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	solution := Solution{Seed: challenge.Seed, Nonce: solve(t, challenge), Signature: challenge.Signature}
+	if err := manager.Check(context.Background(), solution); err != nil {
+		t.Fatalf("first Check: %v", err)
+	}
+	if err := manager.Check(context.Background(), solution); err == nil {
+		t.Error("Check accepted a replayed solution")
+	}
+}
+
+func TestManagerCheckRejectsExpiredChallenge(t *testing.T) {
+	manager := NewManager([]byte("test-secret"), 0)
+	challenge, err := manager.NewChallenge(context.Background(), 0, -time.Minute)
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	solution := Solution{Seed: challenge.Seed, Nonce: solve(t, challenge), Signature: challenge.Signature}
+	if err := manager.Check(context.Background(), solution); err == nil {
+		t.Error("Check accepted an expired challenge")
+	}
+}
+
+func TestManagerCheckRejectsTamperedSignature(t *testing.T) {
+	manager := NewManager([]byte("test-secret"), 0)
+	challenge, err := manager.NewChallenge(context.Background(), 0, time.Minute)
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	solution := Solution{Seed: challenge.Seed, Nonce: solve(t, challenge), Signature: "not-the-real-signature"}
+	if err := manager.Check(context.Background(), solution); err == nil {
+		t.Error("Check accepted a tampered signature")
+	}
+}
+
+func TestManagerCheckRejectsWrongSecret(t *testing.T) {
+	issuer := NewManager([]byte("issuer-secret"), 0)
+	verifier := NewManager([]byte("different-secret"), 0)
+
+	challenge, err := issuer.NewChallenge(context.Background(), 0, time.Minute)
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	solution := Solution{Seed: challenge.Seed, Nonce: solve(t, challenge), Signature: challenge.Signature}
+	if err := verifier.Check(context.Background(), solution); err == nil {
+		t.Error("Check accepted a challenge signed with a different secret")
+	}
+}
+
+func TestReplayCacheClaimEvictsOldestBeyondCapacity(t *testing.T) {
+	cache := newReplayCache(2)
+	future := time.Now().Add(time.Minute)
+
+	if !cache.claim("a", future) {
+		t.Fatal("claim(a) should succeed the first time")
+	}
+	if !cache.claim("b", future) {
+		t.Fatal("claim(b) should succeed the first time")
+	}
+	if !cache.claim("c", future) {
+		t.Fatal("claim(c) should succeed the first time")
+	}
+
+	// "a" should have been evicted to make room for "c", so it can be
+	// claimed again.
+	if !cache.claim("a", future) {
+		t.Error("claim(a) should succeed again after eviction")
+	}
+}
+
+func TestReplayCacheClaimAllowsReuseAfterExpiry(t *testing.T) {
+	cache := newReplayCache(0)
+	past := time.Now().Add(-time.Minute)
+
+	if !cache.claim("key", past) {
+		t.Fatal("claim(key) should succeed the first time")
+	}
+	if !cache.claim("key", time.Now().Add(time.Minute)) {
+		t.Error("claim(key) should succeed again once its entry has expired")
+	}
+}
@@ -0,0 +1,65 @@
+package pow
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// replayCache is a capacity-bounded, TTL-aware LRU of spent (seed, nonce)
+// keys. It stands in for "a Postgres table keyed by seed with a TTL
+// index" when an in-process cache is enough.
+type replayCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type replayEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+const defaultReplayCacheCapacity = 100_000
+
+func newReplayCache(capacity int) *replayCache {
+	if capacity <= 0 {
+		capacity = defaultReplayCacheCapacity
+	}
+	return &replayCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// claim records key as spent until expiresAt, reporting false if it was
+// already claimed and hasn't expired yet.
+func (c *replayCache) claim(key string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*replayEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return false
+		}
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+
+	el := c.ll.PushFront(&replayEntry{key: key, expiresAt: expiresAt})
+	c.items[key] = el
+
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*replayEntry).key)
+	}
+
+	return true
+}